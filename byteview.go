@@ -0,0 +1,33 @@
+package yangcache
+
+// ByteView 保存一份不可变的字节视图，是 Cache 存取的值类型。
+// 不可变意味着 ByteSlice 每次都返回一份拷贝，调用方不会意外改到缓存内部持有的数据
+type ByteView struct {
+	b []byte
+}
+
+// NewByteView 用 b 的一份拷贝构造一个 ByteView，调用方对 b 的后续修改不会影响到缓存
+func NewByteView(b []byte) ByteView {
+	return ByteView{b: cloneBytes(b)}
+}
+
+// Len 返回视图的字节长度
+func (v ByteView) Len() int {
+	return len(v.b)
+}
+
+// ByteSlice 返回底层数据的一份拷贝
+func (v ByteView) ByteSlice() []byte {
+	return cloneBytes(v.b)
+}
+
+// String 将底层数据以字符串形式返回
+func (v ByteView) String() string {
+	return string(v.b)
+}
+
+func cloneBytes(b []byte) []byte {
+	c := make([]byte, len(b))
+	copy(c, b)
+	return c
+}
@@ -2,11 +2,17 @@ package yangcache
 
 import (
 	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
 	"log"
+	"os"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/Satori2Core/yang-cache/metrics"
 	"github.com/Satori2Core/yang-cache/store"
 )
 
@@ -32,8 +38,12 @@ type CacheOptions struct {
 	BucketCount  uint16                              // 分片数量（用于LRU2减少锁竞争）
 	CapPerBucket uint16                              // 每个分片的容量
 	Level2Cap    uint16                              // 二级缓存容量（LRU2特有）
-	CleanupTime  time.Duration                       // 自动清理间隔
+	CleanupTime  time.Duration                       // 自动清理间隔（S3FIFO 用它直接驱动清理 ticker；LRU2 只在 TickInterval 未设置时用它兜底，见 TickInterval）
+	MaxTTL       time.Duration                       // 过期时间轮能覆盖的最大 TTL（LRU2特有）
+	TickInterval time.Duration                       // 过期时间轮的单个桶跨度，同时也是 LRU2 实际的清理粒度（LRU2特有）
 	OnEvicted    func(key string, value store.Value) // 缓存项淘汰回调
+	Collector    metrics.Collector                   // 可观测性采集器，为空时不采集任何指标
+	OnEvent      metrics.EventHook                   // 事件钩子，用于日志或失效传播：只发出 Cache.Get 的命中/未命中和 Cache.Delete 成功时的 manual 淘汰；底层 store 因容量或过期发生的淘汰以及加载事件不会经由它发出
 }
 
 // DefaultCacheOptions 返回默认的缓存配置
@@ -45,6 +55,8 @@ func DefaultCacheOptions() CacheOptions {
 		CapPerBucket: 512,
 		Level2Cap:    256,
 		CleanupTime:  time.Minute,
+		MaxTTL:       24 * time.Hour,
+		TickInterval: time.Second,
 		OnEvicted:    nil,
 	}
 }
@@ -68,6 +80,11 @@ func (c *Cache) ensureInitialized() {
 	defer c.mu.Unlock()
 
 	if c.initialized == 0 {
+		// Collector 留空时退化为什么都不做的实现，调用方不用到处判空
+		if c.opts.Collector == nil {
+			c.opts.Collector = metrics.NopCollector{}
+		}
+
 		// 创建存储选项
 		storeOpts := store.Options{
 			MaxBytes:        c.opts.MaxBytes,
@@ -75,7 +92,10 @@ func (c *Cache) ensureInitialized() {
 			CapPerBucket:    c.opts.CapPerBucket,
 			Level2Cap:       c.opts.Level2Cap,
 			CleanupInterval: c.opts.CleanupTime,
+			MaxTTL:          c.opts.MaxTTL,
+			TickInterval:    c.opts.TickInterval,
 			OnEvicted:       c.opts.OnEvicted,
+			Collector:       c.opts.Collector,
 		}
 
 		// 创建存储实例
@@ -101,6 +121,9 @@ func (c *Cache) Add(key string, value ByteView) {
 	if err := c.store.Set(key, value); err != nil {
 		log.Panicf("Failed to add key %s to cache: %v", key, err)
 	}
+
+	c.opts.Collector.ObserveEntrySize(value.Len())
+	c.syncEntriesGauge()
 }
 
 // Get 缓存读取 - 线程安全
@@ -123,11 +146,15 @@ func (c *Cache) Get(ctx context.Context, key string) (value ByteView, ok bool) {
 	val, found := c.store.Get(key)
 	if !found {
 		atomic.AddInt64(&c.misses, 1)
+		c.opts.Collector.IncMiss(key)
+		metrics.Fire(c.opts.OnEvent, metrics.Event{Type: metrics.EventMiss, Key: key})
 		return ByteView{}, false
 	}
 
 	// 更新命中次数
 	atomic.AddInt64(&c.hits, 1)
+	c.opts.Collector.IncHit(key)
+	metrics.Fire(c.opts.OnEvent, metrics.Event{Type: metrics.EventHit, Key: key})
 
 	// 转换值
 	if bv, ok := val.(ByteView); ok {
@@ -160,7 +187,11 @@ func (c *Cache) AddWithExpiration(key string, value ByteView, expiredAt time.Tim
 	// 设置到底层存储
 	if err := c.store.SetWithExpiration(key, value, expiration); err != nil {
 		log.Printf("Failed to add key %s to cache with expiration: %v", key, err)
+		return
 	}
+
+	c.opts.Collector.ObserveEntrySize(value.Len())
+	c.syncEntriesGauge()
 }
 
 // Delete 从缓存中删除一个 key
@@ -172,7 +203,23 @@ func (c *Cache) Delete(key string) bool {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	return c.store.Delete(key)
+	// manual 淘汰计数和 size 的扣减由 store.Delete 自己上报给同一个 Collector，这里不再重复计数
+	deleted := c.store.Delete(key)
+	if deleted {
+		metrics.Fire(c.opts.OnEvent, metrics.Event{Type: metrics.EventEviction, Key: key, Reason: metrics.ReasonManual})
+		c.syncEntriesGauge()
+	}
+
+	return deleted
+}
+
+// syncEntriesGauge 把当前条目数同步给 Collector，如果它支持的话。SetEntries 不在 Collector
+// 接口里（只有 PromCollector 这类基于 gauge 的实现才有意义），所以用可选接口断言探测，
+// 跟 Close/SaveSnapshot 里探测 Store 可选能力是同一个套路
+func (c *Cache) syncEntriesGauge() {
+	if es, ok := c.opts.Collector.(interface{ SetEntries(int) }); ok {
+		es.SetEntries(c.store.Len())
+	}
 }
 
 // Clear 清空缓存
@@ -189,6 +236,8 @@ func (c *Cache) Clear() {
 	// 重置统计信息
 	atomic.StoreInt64(&c.hits, 0)
 	atomic.StoreInt64(&c.misses, 0)
+
+	c.syncEntriesGauge()
 }
 
 // Len 返回缓存的当前存储项数量
@@ -228,6 +277,201 @@ func (c *Cache) Close() {
 	log.Printf("Cache closed, hits: %d, misses: %d", atomic.LoadInt64(&c.hits), atomic.LoadInt64(&c.misses))
 }
 
+// 快照文件格式：
+//
+//	magic(4) version(1) cacheTypeLen(4)+cacheType entryCount(4)
+//	entryCount 个 [ keyLen(4)+key valLen(4)+val ttlNanos(8) ]
+//	crc32(4)  —— 对 entryCount 个 entry 的字节内容做校验，不含头部
+const (
+	snapshotMagic   uint32 = 0x59434348 // "YCCH"
+	snapshotVersion uint8  = 1
+)
+
+// SaveSnapshot 把缓存中所有存活（未过期）的条目写入 w，供 LoadSnapshot 恢复。
+// 要求底层 store 实现了 Walk 方法（目前 LRU2 和 S3FIFO 都支持）。缓存还没被惰性初始化时
+// 视为空缓存，照样写出一份 entryCount=0 的合法快照，而不是什么都不写——LoadSnapshot
+// 读一个空文件只会报 EOF，那样空缓存的保存/恢复往返就是坏的
+func (c *Cache) SaveSnapshot(w io.Writer) error {
+	type rawEntry struct {
+		key      string
+		val      []byte
+		expireAt int64
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var entries []rawEntry
+
+	if atomic.LoadInt32(&c.initialized) == 1 {
+		walkable, ok := c.store.(interface {
+			Walk(func(key string, value store.Value, expireAt int64) bool)
+		})
+		if !ok {
+			return fmt.Errorf("yangcache: store %T does not support snapshotting", c.store)
+		}
+
+		var walkErr error
+		walkable.Walk(func(key string, value store.Value, expireAt int64) bool {
+			bv, ok := value.(ByteView)
+			if !ok {
+				walkErr = fmt.Errorf("yangcache: unexpected value type %T for key %s", value, key)
+				return false
+			}
+			entries = append(entries, rawEntry{key: key, val: bv.ByteSlice(), expireAt: expireAt})
+			return true
+		})
+		if walkErr != nil {
+			return walkErr
+		}
+	}
+
+	if err := binary.Write(w, binary.BigEndian, snapshotMagic); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, snapshotVersion); err != nil {
+		return err
+	}
+	if err := writeLenPrefixed(w, []byte(c.opts.CacheType)); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, uint32(len(entries))); err != nil {
+		return err
+	}
+
+	crc := crc32.NewIEEE()
+	mw := io.MultiWriter(w, crc)
+
+	now := time.Now().UnixNano()
+	for _, e := range entries {
+		var ttl int64
+		if e.expireAt > 0 {
+			ttl = e.expireAt - now
+		}
+
+		if err := writeLenPrefixed(mw, []byte(e.key)); err != nil {
+			return err
+		}
+		if err := writeLenPrefixed(mw, e.val); err != nil {
+			return err
+		}
+		if err := binary.Write(mw, binary.BigEndian, ttl); err != nil {
+			return err
+		}
+	}
+
+	return binary.Write(w, binary.BigEndian, crc.Sum32())
+}
+
+// LoadSnapshot 从 r 读取快照并恢复到缓存中。载入空缓存时保留各条目原本剩余的 TTL；
+// 载入非空缓存时与现有内容合并，同名 key 以快照内容为准（语义上等价于重放 AddWithExpiration）
+func (c *Cache) LoadSnapshot(r io.Reader) error {
+	c.ensureInitialized()
+
+	var magic uint32
+	if err := binary.Read(r, binary.BigEndian, &magic); err != nil {
+		return err
+	}
+	if magic != snapshotMagic {
+		return fmt.Errorf("yangcache: invalid snapshot magic %#x", magic)
+	}
+
+	var version uint8
+	if err := binary.Read(r, binary.BigEndian, &version); err != nil {
+		return err
+	}
+	if version != snapshotVersion {
+		return fmt.Errorf("yangcache: unsupported snapshot version %d", version)
+	}
+
+	if _, err := readLenPrefixed(r); err != nil { // cacheType，仅作诊断用途，当前不做强校验
+		return err
+	}
+
+	var count uint32
+	if err := binary.Read(r, binary.BigEndian, &count); err != nil {
+		return err
+	}
+
+	crc := crc32.NewIEEE()
+	tr := io.TeeReader(r, crc)
+
+	now := time.Now().UnixNano()
+	for i := uint32(0); i < count; i++ {
+		key, err := readLenPrefixed(tr)
+		if err != nil {
+			return err
+		}
+		val, err := readLenPrefixed(tr)
+		if err != nil {
+			return err
+		}
+
+		var ttl int64
+		if err := binary.Read(tr, binary.BigEndian, &ttl); err != nil {
+			return err
+		}
+
+		if ttl <= 0 {
+			continue // 保存时已无剩余 TTL（或永不过期标记为0的极端竞态），跳过
+		}
+
+		c.AddWithExpiration(string(key), ByteView{b: val}, time.Unix(0, now+ttl))
+	}
+
+	var wantCRC uint32
+	if err := binary.Read(r, binary.BigEndian, &wantCRC); err != nil {
+		return err
+	}
+	if wantCRC != crc.Sum32() {
+		return fmt.Errorf("yangcache: snapshot checksum mismatch")
+	}
+
+	return nil
+}
+
+// SaveToFile 是 SaveSnapshot 的文件版便捷封装
+func (c *Cache) SaveToFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return c.SaveSnapshot(f)
+}
+
+// LoadFromFile 是 LoadSnapshot 的文件版便捷封装
+func (c *Cache) LoadFromFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return c.LoadSnapshot(f)
+}
+
+func writeLenPrefixed(w io.Writer, b []byte) error {
+	if err := binary.Write(w, binary.BigEndian, uint32(len(b))); err != nil {
+		return err
+	}
+	_, err := w.Write(b)
+	return err
+}
+
+func readLenPrefixed(r io.Reader) ([]byte, error) {
+	var n uint32
+	if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
 // Stats 返回缓存统计信息
 func (c *Cache) Stats() map[string]interface{} {
 	stats := map[string]interface{}{
@@ -0,0 +1,78 @@
+// Package metrics 为 yangcache 提供 Cache.Stats() 之外的可观测性能力：
+// 一套采集接口（Collector）、一个开箱即用的 Prometheus 实现，以及用于订阅
+// 命中/未命中/淘汰/加载事件的 OnEvent 钩子。
+package metrics
+
+import "time"
+
+// EventType 标识一次缓存事件的类型
+type EventType int
+
+const (
+	EventHit EventType = iota
+	EventMiss
+	EventEviction
+	EventLoad
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventHit:
+		return "hit"
+	case EventMiss:
+		return "miss"
+	case EventEviction:
+		return "eviction"
+	case EventLoad:
+		return "load"
+	default:
+		return "unknown"
+	}
+}
+
+// EvictionReason 描述一次淘汰的原因
+type EvictionReason string
+
+const (
+	ReasonExpired  EvictionReason = "expired"
+	ReasonCapacity EvictionReason = "capacity"
+	ReasonManual   EvictionReason = "manual"
+)
+
+// Event 是一次可观测事件的快照，通过 EventHook 对外广播
+type Event struct {
+	Type   EventType
+	Key    string
+	Reason EvictionReason // 仅 EventEviction 有意义
+	Dur    time.Duration  // 仅 EventLoad 有意义
+}
+
+// EventHook 由使用方注册，用来做日志、失效传播等旁路处理
+type EventHook func(Event)
+
+// Collector 是缓存观测数据的采集接口，由 Cache 和底层 store 在命中/未命中/淘汰/
+// 加载延迟发生时调用
+type Collector interface {
+	IncHit(key string)
+	IncMiss(key string)
+	IncEviction(key string, reason EvictionReason)
+	ObserveLoadLatency(d time.Duration)
+	ObserveEntrySize(n int)
+}
+
+// NopCollector 是什么都不做的 Collector，作为未配置 Collector 时的默认值，
+// 避免在调用方到处判空
+type NopCollector struct{}
+
+func (NopCollector) IncHit(string) {}
+func (NopCollector) IncMiss(string) {}
+func (NopCollector) IncEviction(string, EvictionReason) {}
+func (NopCollector) ObserveLoadLatency(time.Duration) {}
+func (NopCollector) ObserveEntrySize(int) {}
+
+// Fire 是 EventHook 的 nil-safe 调用方式
+func Fire(hook EventHook, ev Event) {
+	if hook != nil {
+		hook(ev)
+	}
+}
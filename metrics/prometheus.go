@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PromCollector 是基于 github.com/prometheus/client_golang 的 Collector 实现。
+// hits/misses 不按 key 打标签（避免基数爆炸），evictions 按 reason 打标签。
+type PromCollector struct {
+	hits        prometheus.Counter
+	misses      prometheus.Counter
+	evictions   *prometheus.CounterVec
+	loadLatency prometheus.Histogram
+	sizeBytes   prometheus.Gauge
+	entries     prometheus.Gauge
+}
+
+// NewPromCollector 创建一组 <namespace>_* 指标并注册到 reg；namespace 为空时使用 "yangcache"
+func NewPromCollector(reg prometheus.Registerer, namespace string) *PromCollector {
+	if namespace == "" {
+		namespace = "yangcache"
+	}
+
+	c := &PromCollector{
+		hits: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "hits_total",
+			Help:      "Total number of cache hits.",
+		}),
+		misses: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "misses_total",
+			Help:      "Total number of cache misses.",
+		}),
+		evictions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "evictions_total",
+			Help:      "Total number of evicted entries, labeled by reason.",
+		}, []string{"reason"}),
+		loadLatency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "load_latency_seconds",
+			Help:      "Latency of loader calls triggered by cache misses.",
+			Buckets:   prometheus.DefBuckets,
+		}),
+		sizeBytes: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "size_bytes",
+			Help:      "Approximate total size of cached values, in bytes.",
+		}),
+		entries: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "entries",
+			Help:      "Number of entries currently stored in the cache.",
+		}),
+	}
+
+	reg.MustRegister(c.hits, c.misses, c.evictions, c.loadLatency, c.sizeBytes, c.entries)
+
+	return c
+}
+
+func (c *PromCollector) IncHit(string)  { c.hits.Inc() }
+func (c *PromCollector) IncMiss(string) { c.misses.Inc() }
+
+func (c *PromCollector) IncEviction(_ string, reason EvictionReason) {
+	c.evictions.WithLabelValues(string(reason)).Inc()
+}
+
+func (c *PromCollector) ObserveLoadLatency(d time.Duration) { c.loadLatency.Observe(d.Seconds()) }
+
+// ObserveEntrySize 累加 size_bytes gauge；传入负值（例如删除时）会相应减少
+func (c *PromCollector) ObserveEntrySize(n int) { c.sizeBytes.Add(float64(n)) }
+
+// SetEntries 同步当前缓存条目数到 entries gauge，通常由 Cache 在 Add/Delete/Clear 之后调用
+func (c *PromCollector) SetEntries(n int) { c.entries.Set(float64(n)) }
@@ -1,6 +1,12 @@
 package singleflight
 
-import "sync"
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Satori2Core/yang-cache/metrics"
+)
 
 // 代表正在进行或已结束的请求
 type call struct {
@@ -13,6 +19,22 @@ type call struct {
 type Group struct {
 	// 优化并发性能
 	m sync.Map
+
+	// ctxMu/ctxCalls 是 DoCtx/DoChan/Forget 专用的状态，和上面的 m 相互独立：
+	// Do/DoV2 不感知 ctx，没有“放弃等待”“转交 leader”的需求，没必要共用同一套结构
+	ctxMu    sync.Mutex
+	ctxCalls map[string]*ctxCall
+
+	// Collector 为空时不采集任何指标；零值 Group 可以直接使用
+	Collector metrics.Collector
+}
+
+// collector 返回可用的 Collector，Collector 未设置时退化为什么都不做的实现
+func (g *Group) collector() metrics.Collector {
+	if g.Collector != nil {
+		return g.Collector
+	}
+	return metrics.NopCollector{}
 }
 
 // Do ​对于相同的 key，保证在并发情况下只会执行一次 fn 函数
@@ -31,7 +53,9 @@ func (g *Group) Do(key string, fn func() (interface{}, error)) (interface{}, err
 	g.m.Store(key, c) // 把请求放在map里
 
 	// 执行方法与结果存储
+	start := time.Now()
 	c.val, c.err = fn()
+	g.collector().ObserveLoadLatency(time.Since(start))
 	// 标记请求完成
 	c.wg.Done()
 
@@ -62,3 +86,187 @@ func (g *Group) DoV2(key string, fn func() (interface{}, error)) (interface{}, e
 	c.val, c.err = fn()
 	return c.val, c.err
 }
+
+// Result 是 DoChan 投递的结果
+type Result struct {
+	Val    interface{}
+	Err    error
+	Shared bool // 本次结果是否被多个调用者共享
+}
+
+// ctxCall 是 DoCtx/DoChan 使用的请求状态。除了结果本身，还记录了还在等待的调用者的 ctx，
+// 这样当驱动 fn 执行的那个 ctx 被取消时，能从中挑一个还没取消的 ctx 接棒继续跑，而不是
+// 把所有等待者都一起拖死
+type ctxCall struct {
+	mu      sync.Mutex
+	done    chan struct{}
+	val     interface{}
+	err     error
+	dups    int
+	waiters []context.Context
+	chans   []chan<- Result
+}
+
+func newCtxCall() *ctxCall {
+	return &ctxCall{done: make(chan struct{})}
+}
+
+// promote 从还在等待的调用者里挑一个 ctx 尚未取消的，交出去接替当前 leader
+func (c *ctxCall) promote() (context.Context, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for len(c.waiters) > 0 {
+		next := c.waiters[0]
+		c.waiters = c.waiters[1:]
+		if next.Err() == nil {
+			return next, true
+		}
+	}
+
+	return nil, false
+}
+
+// DoCtx 类似 Do，但感知 ctx：调用者可以取消自己的 ctx 放弃等待而不影响其它调用者；
+// 如果当前 leader 的 ctx 被取消、且还有其它调用者在等待，会把其中一个调用者的 ctx
+// 提升为新的 leader ctx，继续等同一次 fn 调用的结果，而不是立即把所有等待者都一起结束。
+// fn 只调用一次，拿到的始终是第一个调用者的 ctx，提升不会替换它：fn 若因这个 ctx 被取消而返回错误，
+// 所有仍在等待的调用者都会拿到这个错误。
+// shared 表示结果是否与其它调用者共享（即存在过重复调用）。
+func (g *Group) DoCtx(ctx context.Context, key string, fn func(ctx context.Context) (interface{}, error)) (v interface{}, err error, shared bool) {
+	g.ctxMu.Lock()
+	if g.ctxCalls == nil {
+		g.ctxCalls = make(map[string]*ctxCall)
+	}
+
+	if c, ok := g.ctxCalls[key]; ok {
+		c.mu.Lock()
+		c.dups++
+		c.waiters = append(c.waiters, ctx)
+		c.mu.Unlock()
+		g.ctxMu.Unlock()
+
+		return g.waitCtx(ctx, c)
+	}
+
+	c := newCtxCall()
+	g.ctxCalls[key] = c
+	g.ctxMu.Unlock()
+
+	go g.drive(ctx, key, c, fn)
+
+	return g.waitCtx(ctx, c)
+}
+
+// DoChan 和 DoCtx 共享同一次 fn 调用，但立即返回一个 channel：结果就绪后写入并关闭该 channel，
+// 调用方可以在 select 里同时等待多个 key，自己决定要不要再叠加超时。
+// 与 DoCtx 不同，调用者自己的 ctx 被取消不会让它的 channel 提前收到结果；只有驱动这次调用的 ctx
+// 被取消且没有其它未取消的等待者时，所有 channel 才会收到该 ctx 的错误
+func (g *Group) DoChan(ctx context.Context, key string, fn func(ctx context.Context) (interface{}, error)) <-chan Result {
+	ch := make(chan Result, 1)
+
+	g.ctxMu.Lock()
+	if g.ctxCalls == nil {
+		g.ctxCalls = make(map[string]*ctxCall)
+	}
+
+	if c, ok := g.ctxCalls[key]; ok {
+		c.mu.Lock()
+		c.dups++
+		c.waiters = append(c.waiters, ctx)
+		c.chans = append(c.chans, ch)
+		c.mu.Unlock()
+		g.ctxMu.Unlock()
+
+		return ch
+	}
+
+	c := newCtxCall()
+	c.chans = append(c.chans, ch)
+	g.ctxCalls[key] = c
+	g.ctxMu.Unlock()
+
+	go g.drive(ctx, key, c, fn)
+
+	return ch
+}
+
+// Forget 让调用方主动使某个正在进行中的调用失效：之后同 key 的 DoCtx/DoChan 会重新触发 fn，
+// 不会再复用这次还没跑完的结果；已经在等待这次调用的调用者不受影响，仍会拿到原本的结果
+func (g *Group) Forget(key string) {
+	g.ctxMu.Lock()
+	delete(g.ctxCalls, key)
+	g.ctxMu.Unlock()
+}
+
+// drive 驱动 fn 执行。fn 在整个调用期间只会被调用一次——即使驱动者的 ctx 被取消，
+// 也不能再起一个新的 fn 调用去替换它，那样一个 key 就会触发两次真正的上游调用，
+// 违背了 singleflight 本该保证的"同一时刻同一个 key 只有一次真正调用"。
+// 所以 ctx 被取消时，只是把 leader 角色转交给还在等待的下一个调用者、继续等同一次
+// fn 调用的结果；转交的是"谁来决定还要不要继续等"，不是重新驱动 fn。
+func (g *Group) drive(ctx context.Context, key string, c *ctxCall, fn func(context.Context) (interface{}, error)) {
+	type outcome struct {
+		val interface{}
+		err error
+	}
+
+	resCh := make(chan outcome, 1)
+	runCtx := ctx // fn 只用这一份 ctx 调用一次；下面循环里重新赋值的 ctx 只用于判断何时停止等待
+	go func() {
+		v, err := fn(runCtx)
+		resCh <- outcome{val: v, err: err}
+	}()
+
+	for {
+		select {
+		case r := <-resCh:
+			g.finishCtx(key, c, r.val, r.err)
+			return
+		case <-ctx.Done():
+			next, ok := c.promote()
+			if !ok {
+				g.finishCtx(key, c, nil, ctx.Err())
+				return
+			}
+			ctx = next // 只是换成下一个还活着的调用者的 ctx 继续等待，fn 本身不会被重新调用
+		}
+	}
+}
+
+func (g *Group) finishCtx(key string, c *ctxCall, val interface{}, err error) {
+	c.mu.Lock()
+	c.val, c.err = val, err
+	shared := c.dups > 0
+	chans := c.chans
+	c.chans = nil
+	c.mu.Unlock()
+
+	g.ctxMu.Lock()
+	if g.ctxCalls[key] == c {
+		delete(g.ctxCalls, key)
+	}
+	g.ctxMu.Unlock()
+
+	close(c.done)
+
+	for _, ch := range chans {
+		ch <- Result{Val: val, Err: err, Shared: shared}
+		close(ch)
+	}
+}
+
+func (g *Group) waitCtx(ctx context.Context, c *ctxCall) (interface{}, error, bool) {
+	select {
+	case <-c.done:
+		c.mu.Lock()
+		shared := c.dups > 0
+		val, err := c.val, c.err
+		c.mu.Unlock()
+		return val, err, shared
+	case <-ctx.Done():
+		c.mu.Lock()
+		shared := c.dups > 0
+		c.mu.Unlock()
+		return nil, ctx.Err(), shared
+	}
+}
@@ -0,0 +1,213 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDoCtxDeduplicatesConcurrentCallers(t *testing.T) {
+	var g Group
+	var calls int32
+
+	release := make(chan struct{})
+	fn := func(ctx context.Context) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return "v", nil
+	}
+
+	const n = 5
+	var wg sync.WaitGroup
+	wg.Add(n)
+
+	results := make([]struct {
+		val    interface{}
+		err    error
+		shared bool
+	}, n)
+
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			defer wg.Done()
+			v, err, shared := g.DoCtx(context.Background(), "k", fn)
+			results[i].val, results[i].err, results[i].shared = v, err, shared
+		}(i)
+	}
+
+	// 给所有调用者一点时间排队到同一个 ctxCall 上
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Fatalf("fn called %d times, want exactly 1", got)
+	}
+
+	sharedCount := 0
+	for _, r := range results {
+		if r.val != "v" || r.err != nil {
+			t.Fatalf("result = %v, %v, want v, nil", r.val, r.err)
+		}
+		if r.shared {
+			sharedCount++
+		}
+	}
+	if sharedCount != n {
+		t.Fatalf("%d/%d results reported shared=true, want all of them", sharedCount, n)
+	}
+}
+
+// TestDoCtxPromotionDoesNotReinvokeFn 覆盖 drive() 的核心约束：leader 的 ctx 被取消、
+// 有其它调用者在等待时，只转交"谁来决定继续等"，fn 本身全程只应该被调用一次
+func TestDoCtxPromotionDoesNotReinvokeFn(t *testing.T) {
+	var g Group
+	var calls int32
+
+	release := make(chan struct{})
+	fn := func(ctx context.Context) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return "v", nil
+	}
+
+	leaderCtx, cancelLeader := context.WithCancel(context.Background())
+
+	leaderDone := make(chan struct{})
+	go func() {
+		defer close(leaderDone)
+		_, _, _ = g.DoCtx(leaderCtx, "k", fn)
+	}()
+
+	// 等 leader 先挂上，再发起一个 follower，确保 follower 进入 waiters
+	time.Sleep(20 * time.Millisecond)
+
+	followerDone := make(chan struct{})
+	var followerVal interface{}
+	var followerErr error
+	go func() {
+		defer close(followerDone)
+		followerVal, followerErr, _ = g.DoCtx(context.Background(), "k", fn)
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	cancelLeader()
+
+	select {
+	case <-leaderDone:
+	case <-time.After(time.Second):
+		t.Fatalf("leader DoCtx did not return after its ctx was canceled")
+	}
+
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Fatalf("fn called %d times after leader cancellation, want exactly 1 (no re-invocation)", got)
+	}
+
+	close(release)
+
+	select {
+	case <-followerDone:
+	case <-time.After(time.Second):
+		t.Fatalf("follower DoCtx did not return after fn finished")
+	}
+
+	if followerVal != "v" || followerErr != nil {
+		t.Fatalf("follower result = %v, %v, want v, nil", followerVal, followerErr)
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Fatalf("fn called %d times overall, want exactly 1", got)
+	}
+}
+
+func TestDoCtxCallerCancellationDoesNotWaitForOthers(t *testing.T) {
+	var g Group
+
+	release := make(chan struct{})
+	defer close(release)
+
+	fn := func(ctx context.Context) (interface{}, error) {
+		<-release
+		return "v", nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err, shared := g.DoCtx(ctx, "k", fn)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if shared {
+		t.Fatalf("shared = true, want false for a sole caller that gave up early (no duplicate callers)")
+	}
+}
+
+func TestDoChanDeliversSharedResult(t *testing.T) {
+	var g Group
+	release := make(chan struct{})
+	fn := func(ctx context.Context) (interface{}, error) {
+		<-release
+		return "v", nil
+	}
+
+	// release 在两次 DoChan 都发起之后才关闭，确保第二次一定是在第一次还没跑完时
+	// 加入进来的 follower，而不是碰巧跟先完成的第一次擦肩而过
+	ch1 := g.DoChan(context.Background(), "k", fn)
+	ch2 := g.DoChan(context.Background(), "k", fn)
+	close(release)
+
+	r1 := <-ch1
+	r2 := <-ch2
+
+	if r1.Err != nil || r2.Err != nil {
+		t.Fatalf("unexpected errors: %v, %v", r1.Err, r2.Err)
+	}
+	if !r1.Shared || !r2.Shared {
+		t.Fatalf("Shared = %v, %v, want both true", r1.Shared, r2.Shared)
+	}
+}
+
+// TestForgetDoesNotAffectTheInFlightCallItself 验证 Forget 只是让"之后"的同 key 调用
+// 重新触发 fn，不会打断正在进行中的这一次调用，也不会影响已经在等它的调用者
+func TestForgetDoesNotAffectTheInFlightCallItself(t *testing.T) {
+	var g Group
+	var calls int32
+	release := make(chan struct{})
+
+	fn := func(ctx context.Context) (interface{}, error) {
+		n := atomic.AddInt32(&calls, 1)
+		<-release
+		return n, nil
+	}
+
+	firstDone := make(chan struct{})
+	var firstVal interface{}
+	go func() {
+		defer close(firstDone)
+		firstVal, _, _ = g.DoCtx(context.Background(), "k", fn)
+	}()
+
+	time.Sleep(20 * time.Millisecond) // 让第一次调用先挂起在 release 上
+
+	g.Forget("k")
+
+	secondVal, _, _ := g.DoCtx(context.Background(), "k", func(ctx context.Context) (interface{}, error) {
+		return int32(99), nil
+	})
+
+	if secondVal != int32(99) {
+		t.Fatalf("DoCtx after Forget reused the in-flight call instead of starting a fresh one: got %v", secondVal)
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Fatalf("original fn invoked %d times, want exactly 1 (Forget must not touch the in-flight call itself)", got)
+	}
+
+	close(release)
+	<-firstDone
+	if firstVal != int32(1) {
+		t.Fatalf("original in-flight caller got %v, want 1 (its own result, unaffected by Forget)", firstVal)
+	}
+}
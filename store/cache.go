@@ -17,17 +17,13 @@ func Now() int64 {
 
 func init() {
 	go func() {
-		// 每秒校准一次
-		atomic.StoreInt64(&clock, time.Now().UnixNano())
-
-		for i := 0; i < 9; i++ {
+		// 每 100ms 校准一次：直接读一次 time.Now() 写回 clock，而不是靠累加 100ms 去逼近，
+		// 这样即使某次调度被耽搁，下一次校准也能纠正累积误差；这个协程要跑满进程的整个生命周期，
+		// 不能只跑几轮就退出，否则 Now() 会永远停在退出那一刻，所有基于它的过期判断都会失效
+		for {
+			atomic.StoreInt64(&clock, time.Now().UnixNano())
 			time.Sleep(100 * time.Millisecond)
-			// 保持 clock 在一个精准的时间范围内，同时避免频繁的系统调用
-			atomic.AddInt64(&clock, int64(100*time.Millisecond))
 		}
-
-		time.Sleep(100 * time.Millisecond)
-
 	}()
 }
 
@@ -40,6 +36,16 @@ func hashBKRD(s string) (hash int32) {
 	return hash
 }
 
+// entrySize 探测被淘汰的值是否支持 Len() int（例如 yangcache.ByteView），用于向
+// Collector.ObserveEntrySize 上报该项的大小；store 包不认识具体的值类型，
+// 只能像探测 Close()/Walk() 那样用可选接口断言，不支持的类型按 0 处理
+func entrySize(v Value) int {
+	if sized, ok := v.(interface{ Len() int }); ok {
+		return sized.Len()
+	}
+	return 0
+}
+
 // 计算大于或等于输入容量的最小2的幂次方减一
 func maskOfNextPowOf2(cap uint16) uint16 {
 	if cap > 0 && cap&(cap-1) == 0 {
@@ -2,20 +2,161 @@ package store
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/Satori2Core/yang-cache/metrics"
+)
+
+// readBufferSize 读缓冲区（环形）大小，必须是 2 的幂
+const readBufferSize = 128
+
+// writeBufferSize 写缓冲区（channel）容量
+const writeBufferSize = 256
+
+const (
+	opSet uint8 = iota
+	opDelete
 )
 
+// accessEvent 记录一次 Get 命中，只用于异步回放 LRU 调整，丢失一次访问提示是安全的
+type accessEvent struct {
+	key   string
+	level int32 // 命中的层级：0=L1，1=L2
+}
+
+// writeEvent 记录一次 Set/Delete，由后台协程回放到 l1/l2 的顺序结构上
+type writeEvent struct {
+	op       uint8
+	key      string
+	value    Value
+	expireAt int64
+}
+
+// valueEntry 是 Get 路径直接读取的值，不经过分片锁
+type valueEntry struct {
+	val      Value
+	expireAt int64
+	level    int32
+}
+
+// readRing 是一个无锁环形缓冲区：写入用 atomic.AddUint32 算出槽位后原子存入，
+// 覆盖太快导致的槽位丢失可以接受——只会让对应 key 的 LRU 位置刷新得晚一点
+type readRing struct {
+	buf  [readBufferSize]atomic.Value
+	head uint32
+}
+
+func (r *readRing) record(key string, level int32) {
+	idx := atomic.AddUint32(&r.head, 1) - 1
+	r.buf[idx&(readBufferSize-1)].Store(accessEvent{key: key, level: level})
+}
+
+// lru2Shard 是单个分片的状态。values 是 Get 的唯一数据来源（sync.Map，无需分片锁），
+// l1/l2 只记录 LRU 顺序和容量淘汰，平时由后台协程 loop 异步维护；
+// mu 在回放事件时短暂持有（loop 协程，或写缓冲区满时 enqueueWrite 在调用方协程里同步回放），
+// Clear 重建 l1/l2 时也会持有，Get 全程不碰它
+type lru2Shard struct {
+	mu     sync.Mutex
+	l1     *cache
+	l2     *cache
+	values sync.Map
+	read   *readRing
+	readAt uint32 // loop 已回放到的读缓冲区游标，仅 loop 协程访问
+	write  chan writeEvent
+}
+
 type lru2Store struct {
-	// 分片锁，每个分片一个互斥锁，减少锁竞争
-	locks []sync.Mutex
-	// 二级缓存数组，每个分片有2个缓存实例，[0]为一级缓存，[1]为二级缓存
-	caches [][2]*cache
-	// 回调函数，当缓存项被淘汰时触发
-	onEvicted func(key string, value Value)
-	// 定时清理过期缓存的定时器
+	shards      []*lru2Shard
+	onEvicted   func(key string, value Value)
+	collector   metrics.Collector
 	cleanupTick *time.Ticker
-	// 分片掩码，用于计算键对应的分片索引
-	mask int32
+	wheel       *timeWheel
+	done        chan struct{}
+	mask        int32
+}
+
+// wheelEntry 是时间轮里的一个待检查条目
+type wheelEntry struct {
+	key      string
+	expireAt int64
+}
+
+// timeWheel 是一个桶式过期轮：cleanupLoop 每个 tick 只需要检查当前指针指向的一个桶，
+// 而不是像之前那样每次都全量扫描所有条目，把清理成本从 O(N) 降到 O(一个桶里的条目数)
+type timeWheel struct {
+	mu         sync.Mutex
+	buckets    [][]wheelEntry
+	tickNanos  int64
+	tickPeriod time.Duration
+	cursor     int
+}
+
+func newTimeWheel(maxTTL, tickInterval time.Duration) *timeWheel {
+	numBuckets := int(maxTTL / tickInterval)
+	if numBuckets < 1 {
+		numBuckets = 1
+	}
+
+	return &timeWheel{
+		buckets:    make([][]wheelEntry, numBuckets),
+		tickNanos:  tickInterval.Nanoseconds(),
+		tickPeriod: tickInterval,
+	}
+}
+
+func (tw *timeWheel) bucketOf(expireAt int64) int {
+	return int((expireAt / tw.tickNanos) % int64(len(tw.buckets)))
+}
+
+// add 把 key 按 expireAt 所在的时间段放入对应的桶
+func (tw *timeWheel) add(key string, expireAt int64) {
+	if expireAt <= 0 {
+		return
+	}
+
+	idx := tw.bucketOf(expireAt)
+
+	tw.mu.Lock()
+	tw.buckets[idx] = append(tw.buckets[idx], wheelEntry{key: key, expireAt: expireAt})
+	tw.mu.Unlock()
+}
+
+// advance 只处理指针当前指向的一个桶：liveExpireAt 返回该 key 此刻真正生效的过期时间
+// （可能因为被重新 Set 而续期了），0 表示该 key 已经不在缓存里、可以直接丢弃这条轮询记录。
+// 已经到期的 key 会被收集返回；尚未到期（说明 TTL 被续期过）的条目会被重新计算桶位置。
+func (tw *timeWheel) advance(now int64, liveExpireAt func(key string) int64) []string {
+	tw.mu.Lock()
+	idx := tw.cursor
+	tw.cursor = (tw.cursor + 1) % len(tw.buckets)
+	bucket := tw.buckets[idx]
+	tw.buckets[idx] = nil
+	tw.mu.Unlock()
+
+	var expired []string
+	var rebucket []wheelEntry
+
+	for _, e := range bucket {
+		cur := liveExpireAt(e.key)
+		switch {
+		case cur <= 0:
+			// key 已被删除或永不过期，这条轮询记录作废
+		case cur != e.expireAt:
+			// TTL 被续期，按新的过期时间重新入轮
+			rebucket = append(rebucket, wheelEntry{key: e.key, expireAt: cur})
+		case cur <= now:
+			expired = append(expired, e.key)
+		default:
+			// 理论上走到这里说明提前触发了，稳妥起见重新入轮等下一圈
+			rebucket = append(rebucket, e)
+		}
+	}
+
+	for _, e := range rebucket {
+		tw.add(e.key, e.expireAt)
+	}
+
+	return expired
 }
 
 func newLRU2Cache(opts Options) *lru2Store {
@@ -35,28 +176,61 @@ func newLRU2Cache(opts Options) *lru2Store {
 		opts.Level2Cap = 1024
 	}
 
+	// TickInterval 才是真正决定时间轮桶宽度/清理粒度的参数（桶宽必须和驱动 advance 的
+	// ticker 周期一致，否则 bucketOf 按桶宽算出的下标会和指针实际走到的时间对不上）。
+	// CleanupInterval 在桶式清理里不再直接驱动 ticker，但为了不让只设置了 CleanupTime、
+	// 没显式设置 TickInterval 的旧配置静默失效，未显式设置 TickInterval 时用它兜底
+	cleanupIntervalSet := opts.CleanupInterval > 0
+
 	if opts.CleanupInterval <= 0 {
 		// 默认每分钟清理一次过期缓存
 		opts.CleanupInterval = time.Minute
 	}
 
+	if opts.MaxTTL <= 0 {
+		// 时间轮能覆盖的最大 TTL，超过这个值的条目仍然会被清理，只是要多转几圈
+		opts.MaxTTL = 24 * time.Hour
+	}
+
+	if opts.TickInterval <= 0 {
+		if cleanupIntervalSet {
+			opts.TickInterval = opts.CleanupInterval
+		} else {
+			opts.TickInterval = time.Second
+		}
+	}
+
+	collector := opts.Collector
+	if collector == nil {
+		collector = metrics.NopCollector{}
+	}
+
 	// 计算大于等于分片数的最小2的幂次方作为掩码，用于高效的取模运算
 	mark := maskOfNextPowOf2(opts.BucketCount)
 
 	s := &lru2Store{
-		locks:       make([]sync.Mutex, mark+1),
-		caches:      make([][2]*cache, mark+1),
+		shards:      make([]*lru2Shard, mark+1),
 		onEvicted:   opts.OnEvicted,
-		cleanupTick: time.NewTicker(opts.CleanupInterval),
+		collector:   collector,
+		cleanupTick: time.NewTicker(opts.TickInterval),
+		wheel:       newTimeWheel(opts.MaxTTL, opts.TickInterval),
+		done:        make(chan struct{}),
 		mask:        int32(mark),
 	}
 
-	// 为每个分片初始化一级和二级缓存
-	for i := range s.caches {
-		// 一级缓存（较小）
-		s.caches[i][0] = Create(opts.CapPerBucket)
-		// 二级缓存（较大）
-		s.caches[i][1] = Create(opts.Level2Cap)
+	// 指针从当前时刻所在的桶出发，之后每个 tick 处理的都是刚过去的那一个 tick 对应的桶
+	s.wheel.cursor = s.wheel.bucketOf(Now())
+
+	// 为每个分片初始化一级、二级缓存及其回放协程
+	for i := range s.shards {
+		sh := &lru2Shard{
+			l1:    Create(opts.CapPerBucket),
+			l2:    Create(opts.Level2Cap),
+			read:  &readRing{},
+			write: make(chan writeEvent, writeBufferSize),
+		}
+		s.shards[i] = sh
+		go sh.loop(s.onEvicted, collector, s.done)
 	}
 
 	// 启动后台清理协程
@@ -67,43 +241,26 @@ func newLRU2Cache(opts Options) *lru2Store {
 	return s
 }
 
+// Get 直接读取 values（sync.Map），不经过分片锁；命中后把访问事件记到读缓冲区，
+// 真正的晋升/LRU 调整交给后台协程异步完成
 func (s *lru2Store) Get(key string) (Value, bool) {
-	// 1. 计算键对应的分片索引（使用哈希和掩码）
-	idx := hashBKDR(key) & s.mask
-	// 锁定分片
-	s.locks[idx].Lock()
-	defer s.locks[idx].Unlock()
+	idx := hashBKRD(key) & s.mask
+	sh := s.shards[idx]
 
-	// 获取当前时间（调用本地实现的内部时钟）
-	currentTime := Now()
-
-	// 2. 首先检查一级缓存（L1缓存）
-	n1, status1, expireAt := s.caches[idx][0].del(key)
-	if status1 > 0 { // 说明该键在L1中
-		// 在一级缓存中找到条目
-		if expireAt > 0 && currentTime >= expireAt {
-			// 项目已过期，执行删除
-			s.delete(key, idx)
-			return nil, false
-		}
-
-		// 条目有效：从L1删除并晋升到L2缓存（LRU策略）
-		s.caches[idx][1].put(key, n1.v, expireAt, s.onEvicted)
+	v, ok := sh.values.Load(key)
+	if !ok {
+		return nil, false
 	}
 
-	// 3. 一级缓存未命中，检查二级缓存
-	n2, status2 := s._get(key, idx, 1) // 在二级缓存中查找
-	if status2 > 0 && n2 != nil {
-		if n2.expireAt > 0 && currentTime >= n2.expireAt {
-			// 二级缓存中的项目已过期
-			s.delete(key, idx)
-			return nil, false
-		}
-		return n2.v, true // 返回二级缓存中的值
+	entry := v.(*valueEntry)
+	if entry.expireAt > 0 && Now() >= entry.expireAt {
+		// 已过期：等待清理协程物理删除，这里只当作未命中
+		return nil, false
 	}
 
-	// 4. 两级缓存都没有
-	return nil, false
+	sh.read.record(key, entry.level)
+
+	return entry.val, true
 }
 
 func (s *lru2Store) Set(key string, value Value) error {
@@ -115,78 +272,108 @@ func (s *lru2Store) SetWithExpiration(key string, value Value, expiration time.D
 	// 计算过期时间戳（0表示永不过期）
 	expireAt := int64(0)
 	if expiration > 0 {
-		// now() 返回纳秒时间戳，确保 expiration 也是纳秒单位
 		expireAt = Now() + int64(expiration.Nanoseconds())
 	}
 
-	// 计算分片并加锁
-	idx := hashBKDR(key) & s.mask
-	s.locks[idx].Lock()
-	defer s.locks[idx].Unlock()
+	idx := hashBKRD(key) & s.mask
+	sh := s.shards[idx]
+
+	// 新数据总是写入一级缓存（L1缓存）；values 立即可见，l1 的顺序结构异步回放
+	sh.values.Store(key, &valueEntry{val: value, expireAt: expireAt, level: 0})
+	sh.enqueueWrite(writeEvent{op: opSet, key: key, value: value, expireAt: expireAt}, s.onEvicted, s.collector)
 
-	// 新数据总是写入一级缓存（L1缓存）
-	s.caches[idx][0].put(key, value, expireAt, s.onEvicted)
+	if expireAt > 0 {
+		s.wheel.add(key, expireAt)
+	}
 
 	return nil
 }
 
 func (s *lru2Store) Delete(key string) bool {
-	idx := hashBKDR(key) & s.mask
-	s.locks[idx].Lock()
-	defer s.locks[idx].Unlock()
+	idx := hashBKRD(key) & s.mask
+	sh := s.shards[idx]
 
-	return s.delete(key, idx)
-}
+	v, ok := sh.values.LoadAndDelete(key)
+	if !ok {
+		return false
+	}
 
-func (s *lru2Store) Clear() {
-	var keys []string
+	sh.enqueueWrite(writeEvent{op: opDelete, key: key}, s.onEvicted, s.collector)
+	s.collector.IncEviction(key, metrics.ReasonManual)
+	s.collector.ObserveEntrySize(-entrySize(v.(*valueEntry).val))
 
-	for i := range s.caches {
-		s.locks[i].Lock()
+	if s.onEvicted != nil {
+		s.onEvicted(key, v.(*valueEntry).val)
+	}
 
-		s.caches[i][0].walk(func(key string, value Value, expireAt int64) bool {
-			keys = append(keys, key)
-			return true
-		})
+	return true
+}
 
-		s.caches[i][1].walk(func(key string, val Value, expireAt int64) bool {
-			// 检查键是否已经收集（避免重复）
-			for _, k := range keys {
-				if key == k {
-					return true
-				}
-			}
-			keys = append(keys, key)
+// enqueueWrite 把写事件交给后台的 loop 协程异步回放；写缓冲区满说明 loop 已经跟不上了，
+// 这时候不能像读事件那样直接丢弃——丢掉一次 Set/Delete 会让 l1/l2 的顺序结构和 values
+// 的实际内容永久对不上（比如一个 key 只存在于 values，永远不会被容量淘汰），
+// 所以退化为跟 loop 用同一把 mu 同步回放这一条，宁可阻塞也不能丢数据
+func (sh *lru2Shard) enqueueWrite(ev writeEvent, onEvicted func(string, Value), collector metrics.Collector) {
+	select {
+	case sh.write <- ev:
+	default:
+		sh.mu.Lock()
+		sh.applyWrite(ev, onEvicted, collector)
+		sh.mu.Unlock()
+	}
+}
+
+func (s *lru2Store) Clear() {
+	for _, sh := range s.shards {
+		sh.values.Range(func(k, _ interface{}) bool {
+			sh.values.Delete(k)
 			return true
 		})
 
-		s.locks[i].Unlock()
-	}
-
-	for _, key := range keys {
-		s.Delete(key)
+		sh.mu.Lock()
+		sh.l1 = Create(uint16(cap(sh.l1.m)))
+		sh.l2 = Create(uint16(cap(sh.l2.m)))
+		sh.mu.Unlock()
 	}
 }
 
 func (s *lru2Store) Len() int {
 	count := 0
 
-	for i := range s.caches {
-		s.locks[i].Lock()
-
-		s.caches[i][0].walk(func(key string, value Value, expireAt int64) bool {
+	for _, sh := range s.shards {
+		sh.values.Range(func(_, _ interface{}) bool {
 			count++
 			return true
 		})
-		s.caches[i][1].walk(func(key string, value Value, expireAt int64) bool {
-			count++
+	}
+
+	return count
+}
+
+// Walk 遍历所有存活（未过期）的条目，walker 返回 false 时提前终止；
+// 用于 Cache 的快照导出，遍历期间不加分片锁，读到的是 values 的一个近似快照
+func (s *lru2Store) Walk(walker func(key string, value Value, expireAt int64) bool) {
+	currentTime := Now()
+
+	for _, sh := range s.shards {
+		stop := false
+
+		sh.values.Range(func(k, v interface{}) bool {
+			entry := v.(*valueEntry)
+			if entry.expireAt > 0 && currentTime >= entry.expireAt {
+				return true
+			}
+			if !walker(k.(string), entry.val, entry.expireAt) {
+				stop = true
+				return false
+			}
 			return true
 		})
 
-		s.locks[i].Unlock()
+		if stop {
+			return
+		}
 	}
-
-	return count
 }
 
 func (s *lru2Store) Close() {
@@ -194,78 +381,130 @@ func (s *lru2Store) Close() {
 		// 终止定时器
 		s.cleanupTick.Stop()
 	}
+
+	close(s.done)
 }
 
-func (s *lru2Store) _get(key string, idx, level int32) (*node, int) {
-	if n, st := s.caches[idx][level].get(key); st > 0 && n != nil {
-		currentTime := Now()
-		if n.expireAt <= 0 || currentTime >= n.expireAt {
-			// 过期或已删除
-			return nil, 0
+// cleanupLoop 每个 tick 只检查时间轮当前指针指向的一个桶，清理成本和缓存总大小无关，
+// 也不需要像以前那样为了扫描全量条目而长时间持有分片锁
+func (s *lru2Store) cleanupLoop() {
+	for range s.cleanupTick.C {
+		now := Now()
+
+		expiredKeys := s.wheel.advance(now, func(key string) int64 {
+			idx := hashBKRD(key) & s.mask
+			v, ok := s.shards[idx].values.Load(key)
+			if !ok {
+				return 0
+			}
+			return v.(*valueEntry).expireAt
+		})
+
+		for _, key := range expiredKeys {
+			idx := hashBKRD(key) & s.mask
+			sh := s.shards[idx]
+
+			v, ok := sh.values.LoadAndDelete(key)
+			if !ok {
+				continue
+			}
+
+			sh.enqueueWrite(writeEvent{op: opDelete, key: key}, s.onEvicted, s.collector)
+			s.collector.IncEviction(key, metrics.ReasonExpired)
+			s.collector.ObserveEntrySize(-entrySize(v.(*valueEntry).val))
+
+			if s.onEvicted != nil {
+				s.onEvicted(key, v.(*valueEntry).val)
+			}
 		}
-		return n, st
 	}
-
-	return nil, 0
 }
 
-func (s *lru2Store) delete(key string, idx int32) bool {
-	n1, s1, _ := s.caches[idx][0].del(key)
-	n2, s2, _ := s.caches[idx][1].del(key)
-	deleted := s1 > 0 || s2 > 0
-
-	if deleted && s.onEvicted != nil {
-		if n1 != nil && n1.v != nil {
-			s.onEvicted(key, n1.v)
-		} else if n2 != nil && n2.v != nil {
-			s.onEvicted(key, n2.v)
+// loop 是每个分片回放事件的后台协程（enqueueWrite 的同步回放和 Clear 之外，只有它修改 l1/l2，
+// 且都在 mu 下进行）：收到写事件立即回放；每个 tick 把读缓冲区里新增的访问事件回放成 LRU 调整/晋升
+func (sh *lru2Shard) loop(onEvicted func(string, Value), collector metrics.Collector, done chan struct{}) {
+	ticker := time.NewTicker(time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case ev := <-sh.write:
+			sh.mu.Lock()
+			sh.applyWrite(ev, onEvicted, collector)
+			sh.mu.Unlock()
+		case <-ticker.C:
+			sh.mu.Lock()
+			sh.applyReads(onEvicted, collector)
+			sh.mu.Unlock()
 		}
 	}
+}
 
-	if deleted {
-		//s.expirations.Delete(key)
+func (sh *lru2Shard) applyWrite(ev writeEvent, onEvicted func(string, Value), collector metrics.Collector) {
+	evictedCb := func(k string, v Value) {
+		sh.values.Delete(k)
+		collector.IncEviction(k, metrics.ReasonCapacity)
+		collector.ObserveEntrySize(-entrySize(v))
+		if onEvicted != nil {
+			onEvicted(k, v)
+		}
 	}
 
-	return deleted
+	switch ev.op {
+	case opSet:
+		// key 可能已经被 applyReads 提升到了 l2（l1 里已经没有它了）。如果这里只管往 l1 put
+		// 而不清理 l2 里的旧节点，l2 那个节点将来自然被容量淘汰时，它的 evictedCb 会按 key
+		// 删除 values——而此时 values 里存的已经是这次 Set 刚写入的新值，于是被误删。
+		// 先在 l2 标记删除（expireAt 置 0），cache.put 的淘汰逻辑只在 expireAt>0 时才回调
+		// onEvicted，这样旧节点将来被挤出 l2 时就不会再触发一次对 values 的误删。
+		sh.l2.del(ev.key)
+		sh.l1.put(ev.key, ev.value, ev.expireAt, evictedCb)
+	case opDelete:
+		sh.l1.del(ev.key)
+		sh.l2.del(ev.key)
+	}
 }
 
-func (s *lru2Store) cleanupLoop() {
-	for range s.cleanupTick.C { // 定时触发清理
-		currentTime := Now()
+// applyReads 回放读缓冲区里、自上次处理以来新增的访问事件
+func (sh *lru2Shard) applyReads(onEvicted func(string, Value), collector metrics.Collector) {
+	head := atomic.LoadUint32(&sh.read.head)
 
-		for i := range s.caches { // 遍历所有分片
-			s.locks[i].Lock()
+	// 游标落后超过一整圈说明对应槽位已经被覆盖，只能跳过丢失的那部分
+	if head-sh.readAt > readBufferSize {
+		sh.readAt = head - readBufferSize
+	}
 
-			// 收集该分片中所有过期的键
-			var expiredKeys []string
+	evictedCb := func(k string, v Value) {
+		sh.values.Delete(k)
+		collector.IncEviction(k, metrics.ReasonCapacity)
+		collector.ObserveEntrySize(-entrySize(v))
+		if onEvicted != nil {
+			onEvicted(k, v)
+		}
+	}
 
-			// 检查一级缓存中的过期项
-			s.caches[i][0].walk(func(key string, value Value, expireAt int64) bool {
-				if expireAt > 0 && currentTime >= expireAt {
-					expiredKeys = append(expiredKeys, key)
-				}
-				return true
-			})
-
-			// 检查二级缓存中的过期项（避免重复）
-			s.caches[i][1].walk(func(key string, value Value, expireAt int64) bool {
-				if expireAt > 0 && currentTime >= expireAt {
-					for _, k := range expiredKeys {
-						if key == k {
-							return true
-						} // 已存在则跳过
+	for ; sh.readAt != head; sh.readAt++ {
+		raw := sh.read.buf[sh.readAt&(readBufferSize-1)].Load()
+		if raw == nil {
+			continue
+		}
+		ev := raw.(accessEvent)
+
+		if ev.level == 0 {
+			// L1 命中：从 L1 摘除并晋升到 L2（与旧版两级缓存语义保持一致）
+			if n, st, expireAt := sh.l1.del(ev.key); st > 0 {
+				sh.l2.put(ev.key, n.v, expireAt, evictedCb)
+				if v, ok := sh.values.Load(ev.key); ok {
+					if existing := v.(*valueEntry); existing.expireAt == expireAt {
+						sh.values.Store(ev.key, &valueEntry{val: existing.val, expireAt: existing.expireAt, level: 1})
 					}
-					expiredKeys = append(expiredKeys, key)
 				}
-				return true
-			})
-
-			// 删除所有过期的键
-			for _, key := range expiredKeys {
-				s.delete(key, int32(i))
 			}
-
-			s.locks[i].Unlock()
+		} else {
+			// L2 命中：只需要刷新 LRU 顺序
+			sh.l2.get(ev.key)
 		}
 	}
 }
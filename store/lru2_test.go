@@ -0,0 +1,154 @@
+package store
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Satori2Core/yang-cache/metrics"
+)
+
+// sizedValue 是一个实现了 Len() int 的测试桩，模拟 yangcache.ByteView——store
+// 包不认识具体的值类型，entrySize 只能靠这个可选接口探测大小
+type sizedValue int
+
+func (v sizedValue) Len() int { return int(v) }
+
+// entrySizeCollector 记录每次 ObserveEntrySize 调用的累计值，其它方法委托给 NopCollector
+type entrySizeCollector struct {
+	metrics.NopCollector
+	total int
+}
+
+func (c *entrySizeCollector) ObserveEntrySize(n int) { c.total += n }
+
+func TestLRU2StoreDeleteReportsNegativeEntrySize(t *testing.T) {
+	collector := &entrySizeCollector{}
+	s := newLRU2Cache(Options{BucketCount: 4, CapPerBucket: 8, Level2Cap: 8, CleanupInterval: time.Hour, Collector: collector})
+	defer s.Close()
+
+	if err := s.Set("a", sizedValue(7)); err != nil {
+		t.Fatalf("Set returned error: %v", err)
+	}
+
+	if !s.Delete("a") {
+		t.Fatalf("Delete(a) = false, want true")
+	}
+
+	// store.Set 本身不上报 size（Cache.Add 才会），所以这里 Delete 应该是唯一一次
+	// ObserveEntrySize 调用，且是负值
+	if collector.total != -7 {
+		t.Fatalf("collector.total = %d, want -7 (Delete must report -Len())", collector.total)
+	}
+}
+
+func TestLRU2StoreSetGetDelete(t *testing.T) {
+	s := newLRU2Cache(Options{BucketCount: 4, CapPerBucket: 8, Level2Cap: 8, CleanupInterval: time.Hour})
+	defer s.Close()
+
+	if err := s.Set("a", "1"); err != nil {
+		t.Fatalf("Set returned error: %v", err)
+	}
+
+	v, ok := s.Get("a")
+	if !ok || v.(string) != "1" {
+		t.Fatalf("Get(a) = %v, %v, want 1, true", v, ok)
+	}
+
+	if !s.Delete("a") {
+		t.Fatalf("Delete(a) = false, want true")
+	}
+
+	if _, ok := s.Get("a"); ok {
+		t.Fatalf("Get(a) after Delete = true, want false")
+	}
+}
+
+// TestLRU2StoreConcurrentAccess 并发地对同一批 key 做 Set/Get/Delete，
+// 覆盖写缓冲区（sh.write）被打满、enqueueWrite 退化为同步回放的路径
+func TestLRU2StoreConcurrentAccess(t *testing.T) {
+	s := newLRU2Cache(Options{BucketCount: 4, CapPerBucket: 16, Level2Cap: 16, CleanupInterval: time.Hour})
+	defer s.Close()
+
+	const goroutines = 32
+	const keys = 8
+
+	var wg sync.WaitGroup
+	wg.Add(goroutines)
+
+	for g := 0; g < goroutines; g++ {
+		go func(id int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				key := string(rune('a' + (i+id)%keys))
+				switch i % 3 {
+				case 0:
+					_ = s.Set(key, id)
+				case 1:
+					s.Get(key)
+				case 2:
+					s.Delete(key)
+				}
+			}
+		}(g)
+	}
+
+	wg.Wait()
+}
+
+// TestLRU2ShardSetAfterPromotionDoesNotLoseValueToStaleL2Eviction 覆盖一个曾经的 bug：
+// Set(k,v1) -> Get(k) 把 k 提升进 l2 -> Set(k,v2) 更新，此时 l1 里是全新节点，l2 里还留着
+// (k,v1) 的旧节点。旧节点后续被 l2 按容量自然淘汰时，若没有在重新 Set 时清理它，
+// 它的 evictedCb 会按 key 把 values 里刚写入的 v2 误删掉。
+func TestLRU2ShardSetAfterPromotionDoesNotLoseValueToStaleL2Eviction(t *testing.T) {
+	sh := &lru2Shard{
+		l1:    Create(4),
+		l2:    Create(1), // 容量为1，方便用一次 put 触发淘汰
+		read:  &readRing{},
+		write: make(chan writeEvent, 4),
+	}
+
+	// Set(k, v1)。expireAt 必须大于 0：cache 把 expireAt==0 的节点当作已删除，
+	// 和真实的 Set 一样给一个正的过期时间，l1.del 才会把它交给 l2
+	sh.values.Store("k", &valueEntry{val: "v1", expireAt: 100, level: 0})
+	sh.applyWrite(writeEvent{op: opSet, key: "k", value: "v1", expireAt: 100}, nil, metrics.NopCollector{})
+
+	// Get(k) 命中 L1：模拟 applyReads 把它提升进 l2
+	sh.read.record("k", 0)
+	sh.applyReads(nil, metrics.NopCollector{})
+
+	if v, ok := sh.values.Load("k"); !ok || v.(*valueEntry).level != 1 {
+		t.Fatalf("setup: k not promoted into l2, test is not exercising the intended scenario")
+	}
+
+	// Set(k, v2)：更新，l1 拿到全新节点，l2 里 (k, v1) 的旧节点此时应被标记删除
+	sh.values.Store("k", &valueEntry{val: "v2", expireAt: 200, level: 0})
+	sh.applyWrite(writeEvent{op: opSet, key: "k", value: "v2", expireAt: 200}, nil, metrics.NopCollector{})
+
+	// 用另一个 key 把 l2（容量1）填满，挤出 (k, v1) 的旧节点
+	evictedCb := func(key string, _ Value) { sh.values.Delete(key) }
+	sh.l2.put("other", "y", 0, evictedCb)
+
+	v, ok := sh.values.Load("k")
+	if !ok {
+		t.Fatalf("values[k] was deleted by the stale l2 node's eviction, want v2 to survive")
+	}
+	if got := v.(*valueEntry).val.(string); got != "v2" {
+		t.Fatalf("values[k] = %v, want v2 (the more recent Set)", got)
+	}
+}
+
+func TestLRU2ShardEnqueueWriteFallsBackWhenBufferFull(t *testing.T) {
+	sh := &lru2Shard{
+		l1:    Create(4),
+		l2:    Create(4),
+		read:  &readRing{},
+		write: make(chan writeEvent), // 无缓冲、无人消费，发送必然走 default 分支
+	}
+
+	sh.enqueueWrite(writeEvent{op: opSet, key: "k", value: "v"}, nil, metrics.NopCollector{})
+
+	if n, status := sh.l1.get("k"); status == 0 || n.v.(string) != "v" {
+		t.Fatalf("enqueueWrite fallback did not synchronously apply the write: status=%d", status)
+	}
+}
@@ -0,0 +1,156 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+// TestS3FIFOShardEvictSmallPromotesAccessedItem 覆盖 evictSmall 的核心分支：
+// small 队首的项如果被访问过（freq>0），应当晋升进 main 而不是直接淘汰进 ghost
+func TestS3FIFOShardEvictSmallPromotesAccessedItem(t *testing.T) {
+	sh := newS3FIFOShard(10) // smallCap=1, mainCap=9
+
+	sh.put("a", "1", 0, nil)
+	if _, ok, _ := sh.get("a"); !ok {
+		t.Fatalf("get(a) = false, want true")
+	} // 访问一次，freq 从 0 升到 1
+
+	var evicted []string
+	sh.put("b", "2", 0, func(key string, _ Value) { evicted = append(evicted, key) }) // small 已满，淘汰队首的 "a"
+
+	if len(evicted) != 0 {
+		t.Fatalf("evicted = %v, want none (promoted item should not fire onEvicted)", evicted)
+	}
+
+	it, ok := sh.items["a"]
+	if !ok {
+		t.Fatalf("item a was dropped, want it promoted into main")
+	}
+	if !it.inMain {
+		t.Fatalf("item a.inMain = false, want true after promotion")
+	}
+	if it.freq != 0 {
+		t.Fatalf("item a.freq = %d, want reset to 0 after promotion", it.freq)
+	}
+}
+
+// TestS3FIFOShardEvictSmallGhostsUnaccessedItem 覆盖 evictSmall 的另一分支：
+// 从未被访问过（freq==0）的项被淘汰时，真正删除并记入 ghost
+func TestS3FIFOShardEvictSmallGhostsUnaccessedItem(t *testing.T) {
+	sh := newS3FIFOShard(10) // smallCap=1, mainCap=9
+
+	sh.put("a", "1", 0, nil) // 从未 get 过，freq 一直是 0
+
+	var evicted []string
+	sh.put("b", "2", 0, func(key string, _ Value) { evicted = append(evicted, key) })
+
+	if len(evicted) != 1 || evicted[0] != "a" {
+		t.Fatalf("evicted = %v, want [a]", evicted)
+	}
+	if _, ok := sh.items["a"]; ok {
+		t.Fatalf("item a still present, want it fully removed")
+	}
+	if _, ok := sh.ghost[hashBKRD("a")]; !ok {
+		t.Fatalf("item a not recorded in ghost after eviction")
+	}
+}
+
+// TestS3FIFOShardGhostHitInsertsDirectlyIntoMain 验证 S3-FIFO 的"回访"语义：
+// 一个 key 被淘汰进 ghost 后很快又被写入，应当跳过 small、直接进入 main
+func TestS3FIFOShardGhostHitInsertsDirectlyIntoMain(t *testing.T) {
+	sh := newS3FIFOShard(10) // smallCap=1, mainCap=9
+
+	sh.put("a", "1", 0, nil)
+	sh.put("b", "2", 0, nil) // small 已满，"a" 从未被访问过，淘汰进 ghost
+
+	if _, ok := sh.items["a"]; ok {
+		t.Fatalf("item a still present before re-insertion, test setup is wrong")
+	}
+	if _, ok := sh.ghost[hashBKRD("a")]; !ok {
+		t.Fatalf("item a not in ghost before re-insertion, test setup is wrong")
+	}
+
+	sh.put("a", "3", 0, nil) // 回访命中 ghost
+
+	it, ok := sh.items["a"]
+	if !ok {
+		t.Fatalf("item a missing after re-insertion")
+	}
+	if !it.inMain {
+		t.Fatalf("item a.inMain = false, want true (ghost hit should insert directly into main)")
+	}
+	if _, ok := sh.ghost[hashBKRD("a")]; ok {
+		t.Fatalf("item a still recorded in ghost after re-insertion, want removed")
+	}
+}
+
+// TestS3FIFOShardEvictMainGivesAccessedItemSecondChance 覆盖 evictMain 的衰减-重排语义：
+// 队首项 freq>0 时只衰减并移到队尾再给一次机会，真正被淘汰的是 freq 降到 0 的那个
+func TestS3FIFOShardEvictMainGivesAccessedItemSecondChance(t *testing.T) {
+	sh := newS3FIFOShard(10) // smallCap=1, mainCap=9
+
+	// 直接构造两个已在 main 里的项，绕开 small->main 的晋升路径，聚焦 evictMain 本身
+	first := &s3fifoItem{key: "first", val: "1", inMain: true, freq: 1}
+	first.elem = sh.main.PushBack(first)
+	sh.items["first"] = first
+
+	for i := 0; i < sh.mainCap-1; i++ {
+		key := string(rune('a' + i))
+		it := &s3fifoItem{key: key, val: i, inMain: true, freq: 0}
+		it.elem = sh.main.PushBack(it)
+		sh.items[key] = it
+	}
+
+	var evicted []string
+	sh.evictMain(func(key string, _ Value) { evicted = append(evicted, key) })
+
+	if len(evicted) != 1 || evicted[0] != "a" {
+		t.Fatalf("evicted = %v, want [a] (first freq>0 item should get a second chance, not be evicted)", evicted)
+	}
+	if _, ok := sh.items["first"]; !ok {
+		t.Fatalf("item \"first\" was evicted, want it requeued with decayed freq instead")
+	}
+	if got := sh.items["first"].freq; got != 0 {
+		t.Fatalf("item \"first\".freq = %d after second chance, want decayed to 0", got)
+	}
+}
+
+func TestS3FIFOStoreSetGetDelete(t *testing.T) {
+	s := newS3FIFOCache(Options{BucketCount: 4, CapPerBucket: 16, CleanupInterval: time.Hour})
+	defer s.Close()
+
+	if err := s.Set("a", "1"); err != nil {
+		t.Fatalf("Set returned error: %v", err)
+	}
+
+	v, ok := s.Get("a")
+	if !ok || v.(string) != "1" {
+		t.Fatalf("Get(a) = %v, %v, want 1, true", v, ok)
+	}
+
+	if !s.Delete("a") {
+		t.Fatalf("Delete(a) = false, want true")
+	}
+
+	if _, ok := s.Get("a"); ok {
+		t.Fatalf("Get(a) after Delete = true, want false")
+	}
+}
+
+func TestS3FIFOStoreDeleteReportsNegativeEntrySize(t *testing.T) {
+	collector := &entrySizeCollector{}
+	s := newS3FIFOCache(Options{BucketCount: 4, CapPerBucket: 16, CleanupInterval: time.Hour, Collector: collector})
+	defer s.Close()
+
+	if err := s.Set("a", sizedValue(5)); err != nil {
+		t.Fatalf("Set returned error: %v", err)
+	}
+
+	if !s.Delete("a") {
+		t.Fatalf("Delete(a) = false, want true")
+	}
+
+	if collector.total != -5 {
+		t.Fatalf("collector.total = %d, want -5 (Delete must report -Len())", collector.total)
+	}
+}
@@ -0,0 +1,58 @@
+package store
+
+import (
+	"time"
+
+	"github.com/Satori2Core/yang-cache/metrics"
+)
+
+// Value 是存入 Store 的值的类型，Store 的实现对值本身不做任何解读，
+// 上层（yangcache.Cache/typedcache.TypedCache）负责把 ByteView 或任意类型塞进来、取出来时断言回去
+type Value interface{}
+
+// CacheType 标识 Store 的底层淘汰策略实现
+type CacheType string
+
+const (
+	// LRU2 是默认策略：一级/二级两层 LRU，访问过一次的 key 才会晋升到二级，
+	// 用来过滤掉只访问一次的冷 key 对主缓存的污染
+	LRU2 CacheType = "lru2"
+	// S3FIFO 见 s3fifo.go 顶部注释，是扫描友好、对倾斜分布命中率更高的替代策略
+	S3FIFO CacheType = "s3fifo"
+)
+
+// Options 是构造具体 Store 实现的参数，字段含义见 yangcache.CacheOptions 中对应字段的注释；
+// 各实现按自己的需要取用，不相关的字段会被忽略
+type Options struct {
+	MaxBytes        int64
+	BucketCount     uint16
+	CapPerBucket    uint16
+	Level2Cap       uint16
+	CleanupInterval time.Duration
+	MaxTTL          time.Duration
+	TickInterval    time.Duration
+	OnEvicted       func(key string, value Value)
+	Collector       metrics.Collector
+}
+
+// Store 是底层缓存存储的统一接口，由 lru2Store/s3fifoStore 等具体策略实现；
+// Walk/Close 等非全部实现都支持的能力通过调用方的可选接口断言来探测，不进入这个核心接口
+type Store interface {
+	Get(key string) (Value, bool)
+	Set(key string, value Value) error
+	SetWithExpiration(key string, value Value, expiration time.Duration) error
+	Delete(key string) bool
+	Clear()
+	Len() int
+}
+
+// NewStore 按 cacheType 构造对应的 Store 实现；未知类型一律回退到 LRU2，
+// 和 DefaultCacheOptions 里 CacheType 的默认值保持一致
+func NewStore(cacheType CacheType, opts Options) Store {
+	switch cacheType {
+	case S3FIFO:
+		return newS3FIFOCache(opts)
+	default:
+		return newLRU2Cache(opts)
+	}
+}
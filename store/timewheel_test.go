@@ -0,0 +1,56 @@
+package store
+
+import "testing"
+
+func TestTimeWheelAdvanceExpiresDueEntries(t *testing.T) {
+	tw := newTimeWheel(10, 1) // 10 个桶，每桶跨度 1ns，方便用整数直接摆弄 expireAt
+
+	tw.add("due", 3)
+	tw.add("not-due", 100) // 落在不同的桶里，advance 只处理当前指针指向的那个桶
+
+	live := map[string]int64{"due": 3, "not-due": 100}
+
+	// 指针从 0 开始，连续推进到 due 所在的桶（bucketOf(3) == 3 % 10 == 3）
+	var expired []string
+	for i := 0; i < 4; i++ {
+		expired = append(expired, tw.advance(3, func(key string) int64 { return live[key] })...)
+	}
+
+	if len(expired) != 1 || expired[0] != "due" {
+		t.Fatalf("advance() expired = %v, want [due]", expired)
+	}
+}
+
+func TestTimeWheelAdvanceRebucketsRenewedEntry(t *testing.T) {
+	tw := newTimeWheel(10, 1)
+	tw.add("k", 2) // 落入 bucket 2
+
+	live := map[string]int64{"k": 50} // liveExpireAt(50) 和入轮时的 expireAt(2) 不一致，说明被续期了
+
+	// 转满两整圈（每圈 10 个桶），确认续期后的条目最终会出现在 bucketOf(50) 对应的桶里，
+	// 而不是在原来的 bucket 2 被直接当成到期处理、或者从此彻底丢失
+	numBuckets := len(tw.buckets)
+	found := false
+	for i := 0; i < 2*numBuckets; i++ {
+		if got := tw.advance(50, func(key string) int64 { return live[key] }); len(got) == 1 && got[0] == "k" {
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		t.Fatalf("renewed entry never reappeared while advancing through two full revolutions")
+	}
+}
+
+func TestTimeWheelAdvanceDropsDeletedEntry(t *testing.T) {
+	tw := newTimeWheel(10, 1)
+	tw.add("gone", 4) // 落入 bucket 4
+
+	// liveExpireAt 返回 0 表示该 key 已经不在缓存里了：转一整圈，bucket 4 那次也不该报告它到期
+	for i := 0; i < len(tw.buckets); i++ {
+		if got := tw.advance(1000, func(string) int64 { return 0 }); len(got) != 0 {
+			t.Fatalf("advance() expired = %v at step %d, want none (entry was deleted)", got, i)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+// Package typedcache 提供一套泛型、类型安全的缓存 API，作为 yangcache.Cache 的补充：
+// yangcache.Cache 只能存取 ByteView，Get 内部还要做一次类型断言；TypedCache 直接
+// 以 Go 泛型保存任意值类型，值不经过 ByteView 包装，让非 []byte 的业务对象可以直接入缓存。
+// TypedCache.Get 同样要对 store 返回的值做一次 entry[V] 类型断言。
+package typedcache
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"time"
+
+	"github.com/Satori2Core/yang-cache/metrics"
+	"github.com/Satori2Core/yang-cache/singleflight"
+	"github.com/Satori2Core/yang-cache/store"
+)
+
+// Hasher 把泛型 key 映射成底层 store.Store 使用的字符串 key
+type Hasher[K comparable] func(key K) string
+
+// DefaultHasher 返回默认的 Hasher：字符串类型的 key 走 FNV-1a，其余类型回退到反射（fmt.Sprintf）
+func DefaultHasher[K comparable]() Hasher[K] {
+	return func(key K) string {
+		if s, ok := any(key).(string); ok {
+			h := fnv.New64a()
+			_, _ = h.Write([]byte(s))
+			return strconv.FormatUint(h.Sum64(), 36)
+		}
+		// 反射兜底：对不是字符串的 key 类型，用其默认格式化结果作为缓存 key
+		return fmt.Sprintf("%v", key)
+	}
+}
+
+// Loader 在缓存未命中时加载数据，配合 singleflight 合并并发的重复加载
+type Loader[K comparable, V any] func(ctx context.Context, key K) (V, error)
+
+// Options 是 TypedCache 的构造参数
+type Options[K comparable, V any] struct {
+	Store     store.Store       // 底层存储，复用 LRU2/S3FIFO 等已有实现
+	Hasher    Hasher[K]         // 为空时使用 DefaultHasher
+	Loader    Loader[K, V]      // 为空时 Get 未命中直接返回 false，不触发加载
+	Collector metrics.Collector // 为空时不采集任何指标
+}
+
+// entry 是实际写入 store.Store 的值，避免把用户的 V 直接暴露给 store.Value 接口
+type entry[V any] struct {
+	val V
+}
+
+// TypedCache 是 Cache 的泛型版本：Get(ctx, K) (V, bool)，不经过 ByteView
+type TypedCache[K comparable, V any] struct {
+	store     store.Store
+	hasher    Hasher[K]
+	loader    Loader[K, V]
+	collector metrics.Collector
+	group     singleflight.Group
+}
+
+// New 创建一个 TypedCache，opts.Store 不能为空
+func New[K comparable, V any](opts Options[K, V]) *TypedCache[K, V] {
+	hasher := opts.Hasher
+	if hasher == nil {
+		hasher = DefaultHasher[K]()
+	}
+
+	collector := opts.Collector
+	if collector == nil {
+		collector = metrics.NopCollector{}
+	}
+
+	return &TypedCache[K, V]{
+		store:     opts.Store,
+		hasher:    hasher,
+		loader:    opts.Loader,
+		collector: collector,
+	}
+}
+
+// Get 优先读缓存；未命中且配置了 Loader 时，通过 singleflight 合并并发请求后回源加载，
+// 加载成功会把结果写回缓存
+func (c *TypedCache[K, V]) Get(ctx context.Context, key K) (V, bool) {
+	var zero V
+
+	hk := c.hasher(key)
+
+	if raw, ok := c.store.Get(hk); ok {
+		if ent, ok := raw.(entry[V]); ok {
+			c.collector.IncHit(hk)
+			return ent.val, true
+		}
+	}
+
+	c.collector.IncMiss(hk)
+
+	if c.loader == nil {
+		return zero, false
+	}
+
+	start := time.Now()
+	// 用 DoCtx 而不是 DoV2：某个调用者的 ctx 被取消时只放弃它自己的等待。
+	// loader 拿到的是第一个发起加载的调用者的 ctx，它被取消后 loader 若据此返回错误，
+	// 其它仍在等待同一个 key 的调用者也会拿到这个错误
+	raw, err, _ := c.group.DoCtx(ctx, hk, func(ctx context.Context) (interface{}, error) {
+		return c.loader(ctx, key)
+	})
+	c.collector.ObserveLoadLatency(time.Since(start))
+	if err != nil {
+		return zero, false
+	}
+
+	val, ok := raw.(V)
+	if !ok {
+		return zero, false
+	}
+
+	c.Add(key, val)
+
+	return val, true
+}
+
+// Add 向缓存中添加一个 kv 对，永不过期
+func (c *TypedCache[K, V]) Add(key K, value V) {
+	_ = c.store.Set(c.hasher(key), entry[V]{val: value})
+}
+
+// AddWithExpiration 向缓存中添加一个带过期时间的 kv 对
+func (c *TypedCache[K, V]) AddWithExpiration(key K, value V, expiredAt time.Time) {
+	expiration := time.Until(expiredAt)
+	if expiration <= 0 {
+		return
+	}
+
+	_ = c.store.SetWithExpiration(c.hasher(key), entry[V]{val: value}, expiration)
+}
+
+// Delete 从缓存中删除一个 key
+func (c *TypedCache[K, V]) Delete(key K) bool {
+	return c.store.Delete(c.hasher(key))
+}